@@ -0,0 +1,17 @@
+package saga
+
+import (
+	"database/sql"
+
+	"github.com/wework/grabbit/gbus"
+)
+
+//CompensatableSaga is an optional interface a gbus.Saga can implement to expose
+//compensating actions for the forward steps it handles, keyed by the FQN of the
+//message that triggered the step. Glue.Start consults it to roll back the completed steps
+//of sagas whose SagaLog shows a forward step that started but never ended.
+type CompensatableSaga interface {
+	gbus.Saga
+	//CompensationFor returns the compensating func registered for handlerName, if any
+	CompensationFor(handlerName string) (fn func(tx *sql.Tx, bus gbus.Bus) error, ok bool)
+}
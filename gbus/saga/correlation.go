@@ -0,0 +1,101 @@
+package saga
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/wework/grabbit/gbus"
+)
+
+//CorrelationStrategy resolves the correlation key/value pair that should be used to look
+//up the saga instance a given message belongs to. It lets a saga correlate on a business
+//key (e.g. OrderID) instead of requiring callers to populate message.SagaCorrelationID, and
+//lets Glue resolve the instance via an indexed Store.GetSagaByCorrelationKey lookup rather
+//than scanning every instance of the saga type. key identifies which correlation column/field
+//value was extracted from, so a single saga type can be correlated on more than one business
+//field across different message types without collisions.
+type CorrelationStrategy interface {
+	//CorrelationKey extracts the correlation key and value carried by message, if any
+	CorrelationKey(message *gbus.BusMessage) (key, value string, ok bool)
+}
+
+type correlationStrategyFunc func(message *gbus.BusMessage) (string, string, bool)
+
+func (f correlationStrategyFunc) CorrelationKey(message *gbus.BusMessage) (string, string, bool) {
+	return f(message)
+}
+
+//ByHeader correlates messages via the value of the named transport header
+func ByHeader(name string) CorrelationStrategy {
+	return correlationStrategyFunc(func(message *gbus.BusMessage) (string, string, bool) {
+		val, exists := message.Headers[name]
+		return name, val, exists
+	})
+}
+
+//ByPayloadField correlates messages via the value of a dotted field path on the message payload
+func ByPayloadField(path string) CorrelationStrategy {
+	return correlationStrategyFunc(func(message *gbus.BusMessage) (string, string, bool) {
+		val, ok := payloadFieldValue(message.Payload, path)
+		return path, val, ok
+	})
+}
+
+//ByCustomFunc correlates messages via an arbitrary caller-supplied func. key identifies this
+//strategy's correlation field (see CorrelationStrategy) and must be unique per message type
+//registered on a given saga type, so two ByCustomFunc registrations extracting different
+//business fields don't share a correlation_key column value and risk returning the wrong
+//instance from Store.GetSagaByCorrelationKey on a coincidental value collision.
+func ByCustomFunc(key string, fn func(message *gbus.BusMessage) string) CorrelationStrategy {
+	return correlationStrategyFunc(func(message *gbus.BusMessage) (string, string, bool) {
+		val := fn(message)
+		return key, val, val != ""
+	})
+}
+
+//correlationRegistryKey scopes a registered CorrelationStrategy by both the saga type and the
+//message type it was registered for, not the message type alone: two different saga types can
+//(and, for event-driven correlation, routinely do) each register their own strategy for the
+//same message, and a msgName-only key would let one saga type's registration silently
+//overwrite, or get dispatched, another's.
+type correlationRegistryKey struct {
+	sagaType reflect.Type
+	msgName  string
+}
+
+//WithCorrelation is a SagaConfFn that registers strategy as the CorrelationStrategy used to
+//look up the saga instance that msg should be routed to, via an indexed Store lookup instead
+//of the default SagaCorrelationID routing or a full GetSagasByType scan. It is passed to
+//RegisterSaga rather than called separately, so a saga's correlation and retry configuration
+//live in the same place.
+func WithCorrelation(msg gbus.Message, strategy CorrelationStrategy) SagaConfFn {
+	return func(glue *Glue, sagaType reflect.Type) {
+		glue.registryLock.Lock()
+		defer glue.registryLock.Unlock()
+		glue.correlations[correlationRegistryKey{sagaType: sagaType, msgName: strings.ToLower(msg.SchemaName())}] = strategy
+	}
+}
+
+func payloadFieldValue(payload interface{}, path string) (string, bool) {
+	val := reflect.ValueOf(payload)
+	for _, part := range strings.Split(path, ".") {
+		for val.Kind() == reflect.Ptr {
+			if val.IsNil() {
+				return "", false
+			}
+			val = val.Elem()
+		}
+		if val.Kind() != reflect.Struct {
+			return "", false
+		}
+		val = val.FieldByName(part)
+		if !val.IsValid() {
+			return "", false
+		}
+	}
+	if !val.CanInterface() {
+		return "", false
+	}
+	return fmt.Sprintf("%v", val.Interface()), true
+}
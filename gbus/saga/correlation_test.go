@@ -0,0 +1,63 @@
+package saga
+
+import "testing"
+
+type correlationTestAddress struct {
+	City string
+}
+
+type correlationTestOrder struct {
+	ID       string
+	Address  correlationTestAddress
+	Ptr      *correlationTestAddress
+	internal string
+}
+
+func TestPayloadFieldValue(t *testing.T) {
+	order := correlationTestOrder{ID: "o-1", Address: correlationTestAddress{City: "Tel Aviv"}}
+
+	cases := []struct {
+		name      string
+		payload   interface{}
+		path      string
+		wantValue string
+		wantOK    bool
+	}{
+		{name: "top level field", payload: order, path: "ID", wantValue: "o-1", wantOK: true},
+		{name: "nested field", payload: order, path: "Address.City", wantValue: "Tel Aviv", wantOK: true},
+		{name: "unknown field", payload: order, path: "Missing", wantOK: false},
+		{name: "unknown nested field", payload: order, path: "Address.Missing", wantOK: false},
+		{name: "non struct payload", payload: "not-a-struct", path: "ID", wantOK: false},
+		{name: "nil pointer along path", payload: correlationTestOrder{Ptr: nil}, path: "Ptr.City", wantOK: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			value, ok := payloadFieldValue(tc.payload, tc.path)
+			if ok != tc.wantOK {
+				t.Fatalf("payloadFieldValue(%v, %q) ok = %v, want %v", tc.payload, tc.path, ok, tc.wantOK)
+			}
+			if ok && value != tc.wantValue {
+				t.Fatalf("payloadFieldValue(%v, %q) = %q, want %q", tc.payload, tc.path, value, tc.wantValue)
+			}
+		})
+	}
+}
+
+func TestPayloadFieldValueUnexportedFieldDoesNotPanic(t *testing.T) {
+	order := correlationTestOrder{internal: "secret"}
+
+	value, ok := payloadFieldValue(order, "internal")
+	if ok {
+		t.Fatalf("payloadFieldValue on an unexported field returned ok = true, value %q, want ok = false", value)
+	}
+}
+
+func TestPayloadFieldValueThroughPointer(t *testing.T) {
+	order := &correlationTestOrder{Ptr: &correlationTestAddress{City: "Haifa"}}
+
+	value, ok := payloadFieldValue(order, "Ptr.City")
+	if !ok || value != "Haifa" {
+		t.Fatalf("payloadFieldValue through pointer = %q, %v, want %q, true", value, ok, "Haifa")
+	}
+}
@@ -28,6 +28,8 @@ func fqnsFromMessages(objs []gbus.Message) []string {
 //ErrInstanceNotFound is returned by the saga store if a saga lookup by saga id returns no valid instances
 var ErrInstanceNotFound = errors.New("saga not be found")
 
+//gbus.SagaGlue's RegisterSaga must be updated alongside this package to accept
+//...SagaConfFn (replacing ...gbus.SagaConfFn) for this assertion to keep compiling
 var _ gbus.SagaGlue = &Glue{}
 
 //Glue t/*  */ies the incoming messages from the Bus with the needed Saga instances
@@ -36,11 +38,17 @@ type Glue struct {
 	svcName          string
 	bus              gbus.Bus
 	sagaDefs         []*Def
-	lock             *sync.Mutex
+	registryLock     *sync.Mutex
+	sagaLocks        *keyedMutex
+	invocations      *invocationTracker
 	alreadyRegistred map[string]bool
 	msgToDefMap      map[string][]*Def
+	correlations     map[correlationRegistryKey]CorrelationStrategy
+	retryPolicies    map[reflect.Type]SagaRetryPolicy
 	sagaStore        Store
+	sagaLog          SagaLog
 	timeoutManager   gbus.TimeoutManager
+	txProvider       gbus.TxProvider
 }
 
 func (imsm *Glue) isSagaAlreadyRegistered(sagaType reflect.Type) bool {
@@ -52,8 +60,14 @@ func (imsm *Glue) isSagaAlreadyRegistered(sagaType reflect.Type) bool {
 	return false
 }
 
+//SagaConfFn configures Glue-level, per-saga-type behavior at RegisterSaga time - the single
+//configuration path for options such as a CorrelationStrategy for one of the saga's message
+//types (see WithCorrelation) or its SagaRetryPolicy (see WithRetryPolicy). It is applied once
+//the saga's Def has been built and registered, so it is free to look up def by sagaType.
+type SagaConfFn func(glue *Glue, sagaType reflect.Type)
+
 //RegisterSaga registers the saga instance with the Bus
-func (imsm *Glue) RegisterSaga(saga gbus.Saga, conf ...gbus.SagaConfFn) error {
+func (imsm *Glue) RegisterSaga(saga gbus.Saga, conf ...SagaConfFn) error {
 
 	sagaType := reflect.TypeOf(saga)
 
@@ -65,12 +79,11 @@ func (imsm *Glue) RegisterSaga(saga gbus.Saga, conf ...gbus.SagaConfFn) error {
 
 	def := &Def{
 
-		glue:        imsm,
-		sagaType:    sagaType,
-		sagaConfFns: conf,
-		startedBy:   fqnsFromMessages(saga.StartedBy()),
-		msgToFunc:   make([]*MsgToFuncPair, 0),
-		lock:        &sync.Mutex{}}
+		glue:      imsm,
+		sagaType:  sagaType,
+		startedBy: fqnsFromMessages(saga.StartedBy()),
+		msgToFunc: make([]*MsgToFuncPair, 0),
+		lock:      &sync.Mutex{}}
 
 	saga.RegisterAllHandlers(def)
 	imsm.sagaDefs = append(imsm.sagaDefs, def)
@@ -80,6 +93,10 @@ func (imsm *Glue) RegisterSaga(saga gbus.Saga, conf ...gbus.SagaConfFn) error {
 		imsm.addMsgNameToDef(msgName, def)
 	}
 
+	for _, confFn := range conf {
+		confFn(imsm, sagaType)
+	}
+
 	imsm.Log().
 		WithFields(logrus.Fields{"saga_type": def.sagaType.String(), "handles_messages": len(msgNames)}).
 		Info("registered saga with messages")
@@ -101,7 +118,23 @@ func (imsm *Glue) getDefsForMsgName(msgName string) []*Def {
 	return defs
 }
 
-func (imsm *Glue) handleNewSaga(def *Def, invocation gbus.Invocation, message *gbus.BusMessage) error {
+//correlationFor resolves the CorrelationStrategy registered for sagaType/message's type, if
+//any, and extracts the key/value pair Store should index the owning instance by. It is
+//consulted once, when a new instance is created (see handleNewSaga), since the indexed
+//correlation_key/value columns are fixed at creation and are not meant to move as the
+//instance is later updated.
+func (imsm *Glue) correlationFor(sagaType reflect.Type, message *gbus.BusMessage) (key, value string, ok bool) {
+	imsm.registryLock.Lock()
+	strategy, hasStrategy := imsm.correlations[correlationRegistryKey{sagaType: sagaType, msgName: strings.ToLower(message.PayloadFQN)}]
+	imsm.registryLock.Unlock()
+
+	if !hasStrategy {
+		return "", "", false
+	}
+	return strategy.CorrelationKey(message)
+}
+
+func (imsm *Glue) handleNewSaga(ctx context.Context, def *Def, invocation gbus.Invocation, message *gbus.BusMessage) error {
 	newInstance := def.newInstance()
 	newInstance.StartedBy = invocation.InvokingSvc()
 	newInstance.StartedBySaga = message.SagaID
@@ -110,9 +143,23 @@ func (imsm *Glue) handleNewSaga(def *Def, invocation gbus.Invocation, message *g
 
 	logInContext := invocation.Log().WithFields(logrus.Fields{"saga_def": def.String(), "saga_id": newInstance.ID})
 
+	if logErr := imsm.sagaLog.StartSaga(invocation.Tx(), newInstance.ID, def.sagaType.String(), message); logErr != nil {
+		logInContext.WithError(logErr).Error("failed to append saga-log start entry")
+		return logErr
+	}
+
+	sagaLock := imsm.sagaLocks.lockFor(newInstance.ID)
+	sagaLock.Lock()
+	defer sagaLock.Unlock()
+
 	logInContext.
 		Info("created new saga")
-	if invkErr := imsm.invokeSagaInstance(def, newInstance, invocation, message); invkErr != nil {
+	logSpanEvent(ctx, "new-saga-created", slog.String("saga_id", newInstance.ID))
+	if invkErr := imsm.invokeWithRetry(ctx, def, newInstance, invocation, message); invkErr != nil {
+		if invkErr == ErrSagaDeadLettered {
+			logInContext.Warn("new saga invocation was dead-lettered, not persisting the instance")
+			return nil
+		}
 		logInContext.Error("failed to invoke saga")
 		return invkErr
 	}
@@ -120,13 +167,15 @@ func (imsm *Glue) handleNewSaga(def *Def, invocation gbus.Invocation, message *g
 	if !newInstance.isComplete() {
 		logInContext.Info("saving new saga")
 
-		if e := imsm.sagaStore.SaveNewSaga(invocation.Tx(), def.sagaType, newInstance); e != nil {
+		correlationKey, correlationValue, _ := imsm.correlationFor(def.sagaType, message)
+		if e := imsm.sagaStore.SaveNewSaga(invocation.Tx(), def.sagaType, newInstance, correlationKey, correlationValue); e != nil {
 			logInContext.Error("saving new saga failed")
 			return e
 		}
 
 		if requestsTimeout, duration := newInstance.requestsTimeout(); requestsTimeout {
 			logInContext.WithField("timeout_duration", duration).Info("new saga requested timeout")
+			logSpanEvent(ctx, "timeout-requested", slog.String("saga_id", newInstance.ID))
 			if tme := imsm.timeoutManager.RegisterTimeout(invocation.Tx(), newInstance.ID, duration); tme != nil {
 				return tme
 			}
@@ -138,27 +187,83 @@ func (imsm *Glue) handleNewSaga(def *Def, invocation gbus.Invocation, message *g
 //SagaHandler is the generic handler invoking saga instances
 func (imsm *Glue) SagaHandler(invocation gbus.Invocation, message *gbus.BusMessage) error {
 
-	imsm.lock.Lock()
-	defer imsm.lock.Unlock()
-	msgName := message.PayloadFQN
+	ctx, done, accepted := imsm.invocations.begin(invocation.Ctx())
+	if !accepted {
+		return errors.New("saga glue is shutting down, rejecting new saga dispatch")
+	}
+	defer done()
+
+	msgName := strings.ToLower(message.PayloadFQN)
 
-	defs := imsm.msgToDefMap[strings.ToLower(msgName)]
+	imsm.registryLock.Lock()
+	defs := imsm.msgToDefMap[msgName]
+	imsm.registryLock.Unlock()
 
 	for _, def := range defs {
+		imsm.registryLock.Lock()
+		strategy, hasStrategy := imsm.correlations[correlationRegistryKey{sagaType: def.sagaType, msgName: msgName}]
+		imsm.registryLock.Unlock()
+
 		/*
 			1) If Def does not have handlers for the message type then log a warning (as this should not happen) and return
 			2) Else if the message is a startup message then create new instance of a saga, invoke startup handler and mark as started
 				2.1) If new instance requests timeouts then reuqest a timeout
-			3) Else if message is destinated for a specific saga instance (reply messages) then find that saga by id and invoke it
-			4) Else if message is not an event drop it (cmd messages should have 1 specific target)
-			5) Else iterate over all instances and invoke the needed handler
+			3) Else if a CorrelationStrategy is registered for the message type then resolve the specific
+				saga instance via an indexed store lookup and invoke it
+			4) Else if message is destinated for a specific saga instance (reply messages) then find that saga by id and invoke it
+			5) Else if message is not an event drop it (cmd messages should have 1 specific target)
+			6) Else iterate over all instances and invoke the needed handler
 		*/
 		logInContext := invocation.Log().WithFields(
 			logrus.Fields{"saga_def": def.String(),
 				"saga_type": def.sagaType})
 		startNew := def.shouldStartNewSaga(message)
 		if startNew {
-			return imsm.handleNewSaga(def, invocation, message)
+			return imsm.handleNewSaga(ctx, def, invocation, message)
+
+		} else if hasStrategy {
+			key, value, ok := strategy.CorrelationKey(message)
+			if !ok {
+				logInContext.Warn("message routed via CorrelationStrategy but no correlation key could be extracted")
+				return nil
+			}
+
+			instance, getErr := imsm.sagaStore.GetSagaByCorrelationKey(invocation.Tx(), def.sagaType, key, value)
+
+			logInContext = logInContext.WithFields(logrus.Fields{"correlation_key": key, "correlation_value": value})
+			if getErr != nil {
+				logInContext.Error("failed to fetch saga by correlation key")
+				return getErr
+			}
+			if instance == nil {
+				logInContext.Warn("message routed via CorrelationStrategy but no saga instance matched the correlation key")
+				return nil
+			}
+			logInContext = logInContext.WithField("saga_id", instance.ID)
+
+			sagaLock := imsm.sagaLocks.lockFor(instance.ID)
+			sagaLock.Lock()
+			var completed bool
+			defer func() {
+				sagaLock.Unlock()
+				if completed {
+					imsm.sagaLocks.release(instance.ID)
+				}
+			}()
+
+			def.configureSaga(instance)
+			if invkErr := imsm.invokeWithRetry(ctx, def, instance, invocation, message); invkErr != nil {
+				if invkErr == ErrSagaDeadLettered {
+					logInContext.Warn("saga invocation was dead-lettered, not persisting the instance")
+					return nil
+				}
+				logInContext.WithError(invkErr).Error("failed to invoke saga")
+				return invkErr
+			}
+
+			var updErr error
+			completed, updErr = imsm.completeOrUpdateSaga(ctx, invocation.Tx(), instance)
+			return updErr
 
 		} else if message.SagaCorrelationID != "" {
 			instance, getErr := imsm.sagaStore.GetSagaByID(invocation.Tx(), message.SagaCorrelationID)
@@ -182,13 +287,30 @@ func (imsm *Glue) SagaHandler(invocation gbus.Invocation, message *gbus.BusMessa
 				return nil
 			}
 			logInContext = logInContext.WithField("saga_id", instance.ID)
+
+			sagaLock := imsm.sagaLocks.lockFor(instance.ID)
+			sagaLock.Lock()
+			var completed bool
+			defer func() {
+				sagaLock.Unlock()
+				if completed {
+					imsm.sagaLocks.release(instance.ID)
+				}
+			}()
+
 			def.configureSaga(instance)
-			if invkErr := imsm.invokeSagaInstance(def, instance, invocation, message); invkErr != nil {
+			if invkErr := imsm.invokeWithRetry(ctx, def, instance, invocation, message); invkErr != nil {
+				if invkErr == ErrSagaDeadLettered {
+					logInContext.Warn("saga invocation was dead-lettered, not persisting the instance")
+					return nil
+				}
 				logInContext.WithError(invkErr).Error("failed to invoke saga")
 				return invkErr
 			}
 
-			return imsm.completeOrUpdateSaga(invocation.Tx(), instance)
+			var updErr error
+			completed, updErr = imsm.completeOrUpdateSaga(ctx, invocation.Tx(), instance)
+			return updErr
 
 		} else if message.Semantics == gbus.CMD {
 			logInContext.Warn("command or reply message with no saga reference received")
@@ -204,12 +326,25 @@ func (imsm *Glue) SagaHandler(invocation gbus.Invocation, message *gbus.BusMessa
 			logInContext.WithFields(logrus.Fields{"instances_fetched": len(instances)}).Info("fetched saga instances")
 
 			for _, instance := range instances {
+				sagaLock := imsm.sagaLocks.lockFor(instance.ID)
+				sagaLock.Lock()
+
 				def.configureSaga(instance)
-				if invkErr := imsm.invokeSagaInstance(def, instance, invocation, message); invkErr != nil {
+				if invkErr := imsm.invokeWithRetry(ctx, def, instance, invocation, message); invkErr != nil {
+					sagaLock.Unlock()
+					if invkErr == ErrSagaDeadLettered {
+						logInContext.Warn("saga invocation was dead-lettered, not persisting the instance")
+						continue
+					}
 					logInContext.WithError(invkErr).Error("failed to invoke saga")
 					return invkErr
 				}
-				e = imsm.completeOrUpdateSaga(invocation.Tx(), instance)
+				var completed bool
+				completed, e = imsm.completeOrUpdateSaga(ctx, invocation.Tx(), instance)
+				sagaLock.Unlock()
+				if completed {
+					imsm.sagaLocks.release(instance.ID)
+				}
 				if e != nil {
 					return e
 				}
@@ -220,11 +355,30 @@ func (imsm *Glue) SagaHandler(invocation gbus.Invocation, message *gbus.BusMessa
 	return nil
 }
 
-func (imsm *Glue) invokeSagaInstance(def *Def, instance *Instance, invocation gbus.Invocation, message *gbus.BusMessage) error {
+func (imsm *Glue) invokeSagaInstance(ctx context.Context, def *Def, instance *Instance, invocation gbus.Invocation, message *gbus.BusMessage) error {
 
-	span, sctx := opentracing.StartSpanFromContext(invocation.Ctx(), def.String())
+	span, sctx := opentracing.StartSpanFromContext(ctx, def.String())
 
 	defer span.Finish()
+
+	rootSagaID := instance.StartedBySaga
+	if rootSagaID == "" {
+		rootSagaID = instance.ID
+	}
+	span.SetTag("saga_id", instance.ID)
+	span.SetTag("saga_type", def.sagaType.String())
+	span.SetTag("message_fqn", message.PayloadFQN)
+	span.SetTag("started_by", instance.StartedBy)
+	span.SetTag("started_by_saga", instance.StartedBySaga)
+	span.SetTag("started_by_message_id", instance.StartedByMessageID)
+	span.SetTag("started_by_rpc_id", instance.StartedByRPCID)
+	span.SetTag("correlation_id", message.SagaCorrelationID)
+	if handlerName := def.handlerNameFor(message.PayloadFQN); handlerName != "" {
+		span.SetTag("handler", handlerName)
+	}
+	span.SetBaggageItem("saga_id", instance.ID)
+	span.SetBaggageItem("root_saga_id", rootSagaID)
+
 	sginv := &sagaInvocation{
 		Glogged:             &gbus.Glogged{},
 		decoratedBus:        invocation.Bus(),
@@ -245,27 +399,43 @@ func (imsm *Glue) invokeSagaInstance(def *Def, instance *Instance, invocation gb
 
 	exchange, routingKey := invocation.Routing()
 	instance.logger = imsm.Log()
+
 	err := instance.invoke(exchange, routingKey, sginv, message)
 	if err != nil {
 		span.LogFields(slog.Error(err))
+		return err
 	}
-	return err
+
+	return imsm.sagaLog.LogMessage(invocation.Tx(), instance.ID, SagaLogEntry{SagaID: instance.ID, EntryType: EndTask, HandlerName: message.PayloadFQN})
 }
 
-func (imsm *Glue) completeOrUpdateSaga(tx *sql.Tx, instance *Instance) error {
+//completeOrUpdateSaga persists instance's final or updated state. It reports whether instance
+//completed via its completed return value but, deliberately, does not itself release
+//instance's entry in imsm.sagaLocks: the caller still holds instance's lock at this point (via
+//an outstanding Lock/defer Unlock), and releasing here would let a concurrent dispatch for the
+//same saga id acquire a brand-new, already-unlocked mutex before this call's own Unlock runs,
+//defeating the per-saga lock entirely. Callers must call imsm.sagaLocks.release(instance.ID)
+//themselves, strictly after their Unlock has actually executed, and only when completed is true.
+func (imsm *Glue) completeOrUpdateSaga(ctx context.Context, tx *sql.Tx, instance *Instance) (completed bool, err error) {
 
 	if instance.isComplete() {
 		imsm.Log().WithField("saga_id", instance.ID).Info("saga has completed and will be deleted")
+		logSpanEvent(ctx, "saga-completed", slog.String("saga_id", instance.ID))
+
+		if logErr := imsm.sagaLog.LogMessage(tx, instance.ID, SagaLogEntry{SagaID: instance.ID, EntryType: EndSaga}); logErr != nil {
+			return false, logErr
+		}
 
 		deleteErr := imsm.sagaStore.DeleteSaga(tx, instance)
 		if deleteErr != nil {
-			return deleteErr
+			return false, deleteErr
 		}
+		logSpanEvent(ctx, "saga-deleted", slog.String("saga_id", instance.ID))
 
-		return imsm.timeoutManager.ClearTimeout(tx, instance.ID)
+		return true, imsm.timeoutManager.ClearTimeout(tx, instance.ID)
 
 	}
-	return imsm.sagaStore.UpdateSaga(tx, instance)
+	return false, imsm.sagaStore.UpdateSaga(tx, instance)
 }
 
 func (imsm *Glue) registerMessage(message gbus.Message) error {
@@ -289,6 +459,22 @@ func (imsm *Glue) registerEvent(exchange, topic string, event gbus.Message) erro
 //TimeoutSaga fetches a saga instance and calls its timeout interface
 func (imsm *Glue) TimeoutSaga(tx *sql.Tx, sagaID string) error {
 
+	ctx, done, accepted := imsm.invocations.begin(context.Background())
+	if !accepted {
+		return errors.New("saga glue is shutting down, rejecting timeout dispatch")
+	}
+	defer done()
+
+	sagaLock := imsm.sagaLocks.lockFor(sagaID)
+	sagaLock.Lock()
+	var completed bool
+	defer func() {
+		sagaLock.Unlock()
+		if completed {
+			imsm.sagaLocks.release(sagaID)
+		}
+	}()
+
 	saga, err := imsm.sagaStore.GetSagaByID(tx, sagaID)
 
 	//we are assuming that if the TimeoutSaga has been called but no instance returned from the store the saga
@@ -300,9 +486,14 @@ func (imsm *Glue) TimeoutSaga(tx *sql.Tx, sagaID string) error {
 		return err
 	}
 
-	span, _ := opentracing.StartSpanFromContext(context.Background(), "SagaTimeout")
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SagaTimeout")
 	span.SetTag("saga_type", saga.String())
 	defer span.Finish()
+
+	if logErr := imsm.sagaLog.LogMessage(tx, sagaID, SagaLogEntry{SagaID: sagaID, EntryType: StartCompensatingTask, HandlerName: "timeout"}); logErr != nil {
+		return logErr
+	}
+
 	timeoutErr := saga.timeout(tx, imsm.bus)
 
 	if timeoutErr != nil {
@@ -310,31 +501,157 @@ func (imsm *Glue) TimeoutSaga(tx *sql.Tx, sagaID string) error {
 		return timeoutErr
 	}
 
+	if logErr := imsm.sagaLog.LogMessage(tx, sagaID, SagaLogEntry{SagaID: sagaID, EntryType: EndCompensatingTask, HandlerName: "timeout"}); logErr != nil {
+		return logErr
+	}
+
 	metrics.SagaTimeoutCounter.Inc()
-	return imsm.completeOrUpdateSaga(tx, saga)
+	var updErr error
+	completed, updErr = imsm.completeOrUpdateSaga(ctx, tx, saga)
+	return updErr
 }
 
 //Start starts the glue instance up
 func (imsm *Glue) Start() error {
+	if recoverErr := imsm.recoverInFlightSagas(); recoverErr != nil {
+		return recoverErr
+	}
 	return imsm.timeoutManager.Start()
 }
 
-//Stop starts the glue instance up
-func (imsm *Glue) Stop() error {
-	return imsm.timeoutManager.Stop()
+//Stop stops accepting new saga dispatches and waits, up to ctx's deadline, for in-flight
+//SagaHandler/invokeSagaInstance/TimeoutSaga calls to finish. If the deadline expires first,
+//their per-invocation contexts are cancelled so they can unwind instead of running unbounded.
+//gbus.SagaGlue's Stop must change from Stop() error to Stop(ctx context.Context) error
+//alongside this package for this method to satisfy that interface.
+func (imsm *Glue) Stop(ctx context.Context) error {
+	drainErr := imsm.invocations.drain(ctx)
+
+	if stopErr := imsm.timeoutManager.Stop(); stopErr != nil {
+		return stopErr
+	}
+
+	return drainErr
+}
+
+//recoverInFlightSagas rebuilds state for sagas that were active when the node crashed and,
+//for the ones whose log shows a forward step that started but never ended, dispatches the
+//compensating handlers of their completed steps in reverse order so the saga reaches a
+//consistent aborted state.
+//Each saga is recovered and committed in its own transaction: compensate(tx, imsm.bus) may
+//have already published messages by the time a later saga in the list fails, so rolling back
+//a single shared transaction across all of them would discard the SagaLog entries for
+//compensations that already took real, non-transactional effect - and would cause them to
+//re-fire on the next restart. A saga that fails to recover is logged and left for the next
+//restart attempt rather than blocking Start for every other saga and the node as a whole.
+func (imsm *Glue) recoverInFlightSagas() error {
+	tx, txErr := imsm.txProvider.New()
+	if txErr != nil {
+		return txErr
+	}
+
+	activeSagaIDs, activeErr := imsm.sagaLog.GetActiveSagas(tx)
+	if activeErr != nil {
+		_ = tx.Rollback()
+		return activeErr
+	}
+	if commitErr := tx.Commit(); commitErr != nil {
+		return commitErr
+	}
+
+	for _, sagaID := range activeSagaIDs {
+		if recoverErr := imsm.recoverSagaInOwnTx(sagaID); recoverErr != nil {
+			imsm.Log().WithError(recoverErr).WithField("saga_id", sagaID).
+				Error("failed to recover saga after crash, leaving it for the next restart attempt")
+		}
+	}
+
+	return nil
+}
+
+//recoverSagaInOwnTx runs recoverSaga in a transaction scoped to sagaID alone, so a failure
+//compensating one saga cannot roll back the log entries already committed for another
+func (imsm *Glue) recoverSagaInOwnTx(sagaID string) error {
+	tx, txErr := imsm.txProvider.New()
+	if txErr != nil {
+		return txErr
+	}
+
+	if recoverErr := imsm.recoverSaga(tx, sagaID); recoverErr != nil {
+		_ = tx.Rollback()
+		return recoverErr
+	}
+
+	return tx.Commit()
+}
+
+func (imsm *Glue) recoverSaga(tx *sql.Tx, sagaID string) error {
+	entries, entriesErr := imsm.sagaLog.GetMessages(tx, sagaID)
+	if entriesErr != nil {
+		return entriesErr
+	}
+
+	if !pendingForwardSteps(entries) {
+		return nil
+	}
+
+	instance, getErr := imsm.sagaStore.GetSagaByID(tx, sagaID)
+	if getErr == ErrInstanceNotFound || (getErr == nil && instance == nil) {
+		imsm.Log().WithField("saga_id", sagaID).Warn("saga-log references a saga that no longer has a store snapshot, skipping recovery")
+		return nil
+	}
+	if getErr != nil {
+		return getErr
+	}
+
+	compensatable, ok := instance.Data.(CompensatableSaga)
+	if !ok {
+		imsm.Log().WithField("saga_id", sagaID).Warn("saga has unfinished steps after a crash but does not implement CompensatableSaga, skipping compensation")
+		return nil
+	}
+
+	completed := completedHandlerNames(entries)
+	imsm.Log().WithFields(logrus.Fields{"saga_id": sagaID, "completed_steps": len(completed)}).Info("compensating incomplete saga after crash recovery")
+
+	for i := len(completed) - 1; i >= 0; i-- {
+		handlerName := completed[i]
+		compensate, hasCompensation := compensatable.CompensationFor(handlerName)
+		if !hasCompensation {
+			continue
+		}
+		if logErr := imsm.sagaLog.LogMessage(tx, sagaID, SagaLogEntry{SagaID: sagaID, EntryType: StartCompensatingTask, HandlerName: handlerName}); logErr != nil {
+			return logErr
+		}
+		if compErr := compensate(tx, imsm.bus); compErr != nil {
+			return compErr
+		}
+		if logErr := imsm.sagaLog.LogMessage(tx, sagaID, SagaLogEntry{SagaID: sagaID, EntryType: EndCompensatingTask, HandlerName: handlerName}); logErr != nil {
+			return logErr
+		}
+	}
+
+	return imsm.sagaLog.LogMessage(tx, sagaID, SagaLogEntry{SagaID: sagaID, EntryType: AbortSaga})
 }
 
-//NewGlue creates a new Sagamanager
-func NewGlue(bus gbus.Bus, sagaStore Store, svcName string, txp gbus.TxProvider, getLog func() logrus.FieldLogger, timeoutManager gbus.TimeoutManager) *Glue {
+//NewGlue creates a new Sagamanager. sagaLog is new in this series: callers in the hosting gbus
+//package must be updated to construct a SagaLog (see NewSagaLog) and pass it here alongside
+//sagaStore.
+func NewGlue(bus gbus.Bus, sagaStore Store, sagaLog SagaLog, svcName string, txp gbus.TxProvider, getLog func() logrus.FieldLogger, timeoutManager gbus.TimeoutManager) *Glue {
 	g := &Glue{
 		svcName:          svcName,
 		bus:              bus,
 		sagaDefs:         make([]*Def, 0),
-		lock:             &sync.Mutex{},
+		registryLock:     &sync.Mutex{},
+		sagaLocks:        newKeyedMutex(),
+		invocations:      newInvocationTracker(),
 		alreadyRegistred: make(map[string]bool),
 		msgToDefMap:      make(map[string][]*Def),
+		correlations:     make(map[correlationRegistryKey]CorrelationStrategy),
+		retryPolicies:    make(map[reflect.Type]SagaRetryPolicy),
 		sagaStore:        sagaStore,
+		sagaLog:          sagaLog,
 		timeoutManager:   timeoutManager,
+		txProvider:       txp,
 	}
 
 	logged := &gbus.Glogged{}
@@ -0,0 +1,62 @@
+package introspect
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/wework/grabbit/gbus"
+	"github.com/wework/grabbit/gbus/saga"
+)
+
+//Handler serves a live view of a Glue's registered sagas and active instances, as
+//JSON by default or as a Graphviz DOT rendering of the saga-message flow graph when
+//called with ?format=dot. It gives operators visibility equivalent to a worker/session
+//admin surface, for debugging stuck or orphaned sagas.
+type Handler struct {
+	glue *saga.Glue
+	txp  gbus.TxProvider
+}
+
+//New creates an introspection http.Handler backed by glue
+func New(glue *saga.Glue, txp gbus.TxProvider) *Handler {
+	return &Handler{glue: glue, txp: txp}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tx, txErr := h.txp.New()
+	if txErr != nil {
+		http.Error(w, txErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	snapshot, snapErr := h.glue.Introspect(tx)
+	if snapErr != nil {
+		http.Error(w, snapErr.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "dot" {
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		_, _ = w.Write([]byte(toDOT(snapshot)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(snapshot)
+}
+
+//toDOT renders the message-to-saga routing table as a Graphviz digraph
+func toDOT(snapshot *saga.Snapshot) string {
+	var b strings.Builder
+	b.WriteString("digraph sagas {\n")
+	for msgName, sagaTypes := range snapshot.MsgToDefRoutes {
+		for _, sagaType := range sagaTypes {
+			fmt.Fprintf(&b, "  %q -> %q;\n", msgName, sagaType)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
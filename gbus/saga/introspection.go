@@ -0,0 +1,95 @@
+package saga
+
+import (
+	"database/sql"
+	"time"
+)
+
+//SagaDefSnapshot describes a registered saga Def for introspection purposes
+type SagaDefSnapshot struct {
+	SagaType        string
+	StartedBy       []string
+	HandledMessages []string
+}
+
+//SagaInstanceSnapshot describes the live state of a single saga instance
+type SagaInstanceSnapshot struct {
+	ID                 string
+	SagaType           string
+	StartedBy          string
+	StartedBySaga      string
+	StartedByMessageID string
+	//History is the instance's full ordered SagaLog, so a stuck or orphaned saga (see the
+	//completion-on-other-nodes discussion in issue-196) can be diagnosed from its handled
+	//messages and compensations without a separate query against the saga log table
+	History []SagaLogEntry
+	//PendingTimeoutAt is when the TimeoutManager will next fire a timeout for this instance,
+	//or nil if none is currently scheduled
+	PendingTimeoutAt *time.Time
+}
+
+//Snapshot is a point-in-time view of everything Glue knows about: registered Defs,
+//currently active instances and the message-to-Def routing table. It backs the
+//saga/introspect HTTP endpoint.
+type Snapshot struct {
+	Defs           []SagaDefSnapshot
+	Instances      []SagaInstanceSnapshot
+	MsgToDefRoutes map[string][]string
+}
+
+//Introspect returns a Snapshot of every registered saga Def, every currently active
+//saga instance and the message-to-Def routing table. It exists to debug stuck or
+//orphaned sagas - see the completion-on-other-nodes discussion in
+//https://github.com/wework/grabbit/issues/196.
+func (imsm *Glue) Introspect(tx *sql.Tx) (*Snapshot, error) {
+	imsm.registryLock.Lock()
+	defs := make([]SagaDefSnapshot, 0, len(imsm.sagaDefs))
+	for _, def := range imsm.sagaDefs {
+		defs = append(defs, SagaDefSnapshot{
+			SagaType:        def.sagaType.String(),
+			StartedBy:       def.startedBy,
+			HandledMessages: def.getHandledMessages(),
+		})
+	}
+	routes := make(map[string][]string, len(imsm.msgToDefMap))
+	for msgName, defsForMsg := range imsm.msgToDefMap {
+		sagaTypes := make([]string, 0, len(defsForMsg))
+		for _, def := range defsForMsg {
+			sagaTypes = append(sagaTypes, def.sagaType.String())
+		}
+		routes[msgName] = sagaTypes
+	}
+	imsm.registryLock.Unlock()
+
+	active, err := imsm.sagaStore.GetAllActiveSagas(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make([]SagaInstanceSnapshot, 0, len(active))
+	for _, instance := range active {
+		history, histErr := imsm.sagaLog.GetMessages(tx, instance.ID)
+		if histErr != nil {
+			return nil, histErr
+		}
+
+		//GetPendingTimeout is new: gbus.TimeoutManager must be extended with it alongside this
+		//package for Introspect to compile against it
+		pendingTimeoutAt, timeoutErr := imsm.timeoutManager.GetPendingTimeout(tx, instance.ID)
+		if timeoutErr != nil {
+			return nil, timeoutErr
+		}
+
+		instances = append(instances, SagaInstanceSnapshot{
+			ID:                 instance.ID,
+			SagaType:           instance.String(),
+			StartedBy:          instance.StartedBy,
+			StartedBySaga:      instance.StartedBySaga,
+			StartedByMessageID: instance.StartedByMessageID,
+			History:            history,
+			PendingTimeoutAt:   pendingTimeoutAt,
+		})
+	}
+
+	return &Snapshot{Defs: defs, Instances: instances, MsgToDefRoutes: routes}, nil
+}
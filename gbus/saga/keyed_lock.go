@@ -0,0 +1,34 @@
+package saga
+
+import "sync"
+
+//keyedMutex hands out a *sync.Mutex per key so that dispatches against different
+//saga instances do not serialize behind each other, mirroring the per-worker lock
+//pattern used elsewhere for session lifecycle management
+type keyedMutex struct {
+	guard *sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{guard: &sync.Mutex{}, locks: make(map[string]*sync.Mutex)}
+}
+
+func (k *keyedMutex) lockFor(key string) *sync.Mutex {
+	k.guard.Lock()
+	defer k.guard.Unlock()
+	m, exists := k.locks[key]
+	if !exists {
+		m = &sync.Mutex{}
+		k.locks[key] = m
+	}
+	return m
+}
+
+//release drops the lock kept for key once the saga it guards is gone, so the map
+//does not grow without bound over the lifetime of the process
+func (k *keyedMutex) release(key string) {
+	k.guard.Lock()
+	defer k.guard.Unlock()
+	delete(k.locks, key)
+}
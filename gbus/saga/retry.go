@@ -0,0 +1,186 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wework/grabbit/gbus"
+)
+
+//ErrSagaDeadLettered is returned by invokeWithRetry when it exhausted its SagaRetryPolicy and
+//routed the message to the dead-letter exchange instead of succeeding. Callers must treat it
+//as "handled, stop processing this instance" rather than as a real invocation failure: the
+//instance must not be saved/updated as if the invocation that was just dead-lettered succeeded,
+//but the message itself should not be nacked back to the bus for another round of retries either.
+var ErrSagaDeadLettered = errors.New("saga invocation exhausted its retry policy and was dead-lettered")
+
+//ErrNoFailedSaga is returned by ResumeFailedSaga when sagaID has no stored failed-message
+//row, e.g. a typo'd saga ID. Resuming does not remove the stored row, so a saga that was
+//already resumed can be resumed again
+var ErrNoFailedSaga = errors.New("saga has no recorded failed message to resume")
+
+//SagaRetryPolicy configures how Glue retries a saga invocation that returned an error before
+//giving up, recording the failure and routing the original message to a dead-letter exchange.
+//Unlike the bus's own message-level retry, it operates purely on the saga-handling error and
+//does not re-run GetSagaByID/routing on every attempt.
+type SagaRetryPolicy struct {
+	//MaxAttempts is the total number of times invokeSagaInstance is called, including the first
+	MaxAttempts int
+	//InitialBackoff is the delay before the second attempt; it doubles on every further attempt
+	InitialBackoff time.Duration
+	//MaxBackoff caps the exponential backoff delay
+	MaxBackoff time.Duration
+	//Classifier decides whether err is worth retrying; a nil Classifier retries every error
+	Classifier func(err error) bool
+	//DeadLetterExchange is published to, with x-saga-id/x-failure-reason headers, once
+	//MaxAttempts is exhausted or Classifier rejects the error. An empty value disables
+	//dead-lettering and surfaces the last error
+	DeadLetterExchange string
+}
+
+//defaultRetryPolicy preserves the historical behavior of bubbling the first error straight out
+//to the bus's own message-level retry
+var defaultRetryPolicy = SagaRetryPolicy{MaxAttempts: 1}
+
+func (p SagaRetryPolicy) backoffFor(attempt int) time.Duration {
+	if p.InitialBackoff == 0 {
+		return 0
+	}
+	backoff := p.InitialBackoff * time.Duration(math.Pow(2, float64(attempt-1)))
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return backoff
+}
+
+func (p SagaRetryPolicy) shouldRetry(err error) bool {
+	if p.Classifier == nil {
+		return true
+	}
+	return p.Classifier(err)
+}
+
+//retryPolicyFor returns the SagaRetryPolicy registered for sagaType, or defaultRetryPolicy
+func (imsm *Glue) retryPolicyFor(sagaType reflect.Type) SagaRetryPolicy {
+	imsm.registryLock.Lock()
+	defer imsm.registryLock.Unlock()
+	if policy, exists := imsm.retryPolicies[sagaType]; exists {
+		return policy
+	}
+	return defaultRetryPolicy
+}
+
+//WithRetryPolicy is a SagaConfFn that configures the retry/backoff/dead-letter policy Glue
+//applies around invocations of instances of this saga type. It is passed to RegisterSaga
+//rather than called separately, so a saga's retry and correlation configuration (see
+//WithCorrelation) live in the same place. A MaxAttempts below 1 is clamped to 1, since
+//invokeWithRetry's loop never invoking the saga at all would leave a StartTask log entry
+//with no matching EndTask, which crash recovery would misread as a forward step still pending.
+func WithRetryPolicy(policy SagaRetryPolicy) SagaConfFn {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	return func(glue *Glue, sagaType reflect.Type) {
+		glue.registryLock.Lock()
+		defer glue.registryLock.Unlock()
+		glue.retryPolicies[sagaType] = policy
+	}
+}
+
+//invokeWithRetry wraps invokeSagaInstance with def's retry policy, recording a permanent
+//failure and dead-lettering the inbound message once the policy is exhausted. It returns
+//ErrSagaDeadLettered, not nil, once dead-lettering succeeds, so callers can tell a genuinely
+//successful invocation apart from one that was shipped to the dead-letter exchange instead.
+//
+//The StartTask SagaLog entry for this logical step is written once here, before the retry
+//loop, rather than once per attempt inside invokeSagaInstance: recoverSaga's
+//pendingForwardSteps compares raw StartTask/EndTask counts, so logging a fresh StartTask on
+//every retried attempt would leave a step that eventually succeeded with more StartTask than
+//EndTask entries, and a later crash would wrongly compensate it as still pending.
+func (imsm *Glue) invokeWithRetry(ctx context.Context, def *Def, instance *Instance, invocation gbus.Invocation, message *gbus.BusMessage) error {
+	policy := imsm.retryPolicyFor(def.sagaType)
+
+	if logErr := imsm.sagaLog.LogMessage(invocation.Tx(), instance.ID, SagaLogEntry{SagaID: instance.ID, EntryType: StartTask, HandlerName: message.PayloadFQN}); logErr != nil {
+		return logErr
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			if waitErr := interruptibleSleep(ctx, policy.backoffFor(attempt-1)); waitErr != nil {
+				return waitErr
+			}
+		}
+		lastErr = imsm.invokeSagaInstance(ctx, def, instance, invocation, message)
+		if lastErr == nil {
+			return nil
+		}
+		if !policy.shouldRetry(lastErr) {
+			break
+		}
+	}
+
+	if policy.DeadLetterExchange == "" {
+		return lastErr
+	}
+
+	if dlErr := imsm.deadLetter(invocation, instance, message, lastErr, policy); dlErr != nil {
+		return dlErr
+	}
+	return ErrSagaDeadLettered
+}
+
+//interruptibleSleep waits for d, returning early with ctx's error if ctx is done first. The
+//caller may be holding the message's open DB transaction and a per-saga-ID lock (see
+//keyed_lock.go) for the duration of the wait, so a plain time.Sleep here would make Stop's
+//shutdown deadline (shutdown.go) unable to unwind an invocation stuck in backoff.
+func interruptibleSleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (imsm *Glue) deadLetter(invocation gbus.Invocation, instance *Instance, message *gbus.BusMessage, cause error, policy SagaRetryPolicy) error {
+	if markErr := imsm.sagaStore.MarkFailed(invocation.Tx(), instance, message, cause); markErr != nil {
+		return markErr
+	}
+
+	if message.Headers == nil {
+		message.Headers = make(map[string]string)
+	}
+	message.Headers["x-saga-id"] = instance.ID
+	message.Headers["x-failure-reason"] = cause.Error()
+
+	if pubErr := imsm.bus.Publish(invocation.Ctx(), policy.DeadLetterExchange, "", message); pubErr != nil {
+		return pubErr
+	}
+
+	imsm.Log().WithFields(logrus.Fields{"saga_id": instance.ID, "cause": cause}).
+		Warn("saga invocation exhausted its retry policy, message routed to dead-letter exchange")
+	return nil
+}
+
+//ResumeFailedSaga re-delivers the message stored for a saga marked failed through SagaHandler,
+//for use after manual intervention has addressed the cause of the failure
+func (imsm *Glue) ResumeFailedSaga(invocation gbus.Invocation, sagaID string) error {
+	message, getErr := imsm.sagaStore.GetFailedSagaMessage(invocation.Tx(), sagaID)
+	if getErr != nil {
+		return getErr
+	}
+	if message == nil {
+		return ErrNoFailedSaga
+	}
+	return imsm.SagaHandler(invocation, message)
+}
@@ -0,0 +1,96 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSagaRetryPolicyBackoffFor(t *testing.T) {
+	policy := SagaRetryPolicy{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}
+
+	cases := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: time.Second},
+		{attempt: 2, want: 2 * time.Second},
+		{attempt: 3, want: 4 * time.Second},
+		{attempt: 4, want: 8 * time.Second},
+		{attempt: 5, want: 10 * time.Second}, // capped by MaxBackoff
+		{attempt: 10, want: 10 * time.Second},
+	}
+
+	for _, tc := range cases {
+		if got := policy.backoffFor(tc.attempt); got != tc.want {
+			t.Errorf("backoffFor(%d) = %v, want %v", tc.attempt, got, tc.want)
+		}
+	}
+}
+
+func TestSagaRetryPolicyBackoffForNoInitialBackoff(t *testing.T) {
+	policy := SagaRetryPolicy{}
+	if got := policy.backoffFor(3); got != 0 {
+		t.Errorf("backoffFor(3) = %v, want 0 when InitialBackoff is unset", got)
+	}
+}
+
+func TestSagaRetryPolicyBackoffForNoMaxBackoff(t *testing.T) {
+	policy := SagaRetryPolicy{InitialBackoff: time.Second}
+	if got := policy.backoffFor(6); got != 32*time.Second {
+		t.Errorf("backoffFor(6) = %v, want %v when MaxBackoff is unset", got, 32*time.Second)
+	}
+}
+
+func TestSagaRetryPolicyShouldRetry(t *testing.T) {
+	errTransient := errors.New("transient")
+	errPermanent := errors.New("permanent")
+
+	withClassifier := SagaRetryPolicy{
+		Classifier: func(err error) bool { return err == errTransient },
+	}
+	if !withClassifier.shouldRetry(errTransient) {
+		t.Error("shouldRetry(errTransient) = false, want true")
+	}
+	if withClassifier.shouldRetry(errPermanent) {
+		t.Error("shouldRetry(errPermanent) = true, want false")
+	}
+
+	withoutClassifier := SagaRetryPolicy{}
+	if !withoutClassifier.shouldRetry(errPermanent) {
+		t.Error("shouldRetry with a nil Classifier = false, want true for any error")
+	}
+}
+
+func TestInterruptibleSleepCompletesAfterDuration(t *testing.T) {
+	ctx := context.Background()
+	start := time.Now()
+	if err := interruptibleSleep(ctx, 10*time.Millisecond); err != nil {
+		t.Fatalf("interruptibleSleep returned %v, want nil", err)
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Fatalf("interruptibleSleep returned after %v, want at least 10ms", elapsed)
+	}
+}
+
+func TestInterruptibleSleepReturnsOnCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := interruptibleSleep(ctx, time.Hour); err == nil {
+		t.Fatal("interruptibleSleep returned nil for an already-cancelled context, want an error")
+	}
+}
+
+func TestInterruptibleSleepZeroDurationChecksContext(t *testing.T) {
+	if err := interruptibleSleep(context.Background(), 0); err != nil {
+		t.Fatalf("interruptibleSleep(0) with a live context returned %v, want nil", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := interruptibleSleep(ctx, 0); err == nil {
+		t.Fatal("interruptibleSleep(0) with a cancelled context returned nil, want an error")
+	}
+}
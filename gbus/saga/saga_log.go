@@ -0,0 +1,155 @@
+package saga
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/wework/grabbit/gbus"
+)
+
+//SagaLogEntryType enumerates the lifecycle events recorded against a saga instance
+type SagaLogEntryType int
+
+const (
+	//StartSaga marks the creation of a new saga instance
+	StartSaga SagaLogEntryType = iota
+	//StartTask marks the start of a forward saga step
+	StartTask
+	//EndTask marks the successful completion of a forward saga step
+	EndTask
+	//StartCompensatingTask marks the start of a compensating step
+	StartCompensatingTask
+	//EndCompensatingTask marks the successful completion of a compensating step
+	EndCompensatingTask
+	//EndSaga marks the successful completion of a saga
+	EndSaga
+	//AbortSaga marks a saga being aborted and rolled back
+	AbortSaga
+)
+
+//SagaLogEntry is a single append-only event recorded against a saga instance
+type SagaLogEntry struct {
+	SagaID      string
+	EntryType   SagaLogEntryType
+	HandlerName string
+}
+
+//SagaLog is a persistent, append-only log of saga lifecycle events, kept
+//alongside the Store snapshot so that after a crash a node can rebuild the
+//state of in-flight sagas and compensate the ones that died mid-flight
+type SagaLog interface {
+	//StartSaga durably records the creation of sagaID
+	StartSaga(tx *sql.Tx, sagaID, sagaType string, startMsg *gbus.BusMessage) error
+	//LogMessage appends entry to the history kept for sagaID
+	LogMessage(tx *sql.Tx, sagaID string, entry SagaLogEntry) error
+	//GetMessages returns the full ordered history recorded for sagaID
+	GetMessages(tx *sql.Tx, sagaID string) ([]SagaLogEntry, error)
+	//GetActiveSagas returns the ids of sagas with a StartSaga entry but no EndSaga/AbortSaga entry
+	GetActiveSagas(tx *sql.Tx) ([]string, error)
+	//EnsureSchema creates the backing table if it does not already exist. Callers must invoke
+	//it once against db at service startup, before the first StartSaga/LogMessage call
+	EnsureSchema(db *sql.DB) error
+}
+
+//sqlSagaLog is the default SagaLog, backed by a SQL table living alongside the Store's own tables
+type sqlSagaLog struct {
+	tableName string
+}
+
+//NewSagaLog creates a SagaLog that persists its entries to a table scoped to svcName
+func NewSagaLog(svcName string) SagaLog {
+	return &sqlSagaLog{tableName: fmt.Sprintf("%s_saga_log", svcName)}
+}
+
+func (l *sqlSagaLog) EnsureSchema(db *sql.DB) error {
+	_, err := db.Exec(fmt.Sprintf(
+		`CREATE TABLE IF NOT EXISTS %s (
+			id SERIAL PRIMARY KEY,
+			saga_id VARCHAR NOT NULL,
+			saga_type VARCHAR NOT NULL DEFAULT '',
+			entry_type INTEGER NOT NULL,
+			handler_name VARCHAR NOT NULL DEFAULT ''
+		)`, l.tableName))
+	return err
+}
+
+func (l *sqlSagaLog) StartSaga(tx *sql.Tx, sagaID, sagaType string, startMsg *gbus.BusMessage) error {
+	_, err := tx.Exec(fmt.Sprintf("INSERT INTO %s (saga_id, saga_type, entry_type, handler_name) VALUES ($1, $2, $3, '')", l.tableName),
+		sagaID, sagaType, StartSaga)
+	return err
+}
+
+func (l *sqlSagaLog) LogMessage(tx *sql.Tx, sagaID string, entry SagaLogEntry) error {
+	_, err := tx.Exec(fmt.Sprintf("INSERT INTO %s (saga_id, entry_type, handler_name) VALUES ($1, $2, $3)", l.tableName),
+		sagaID, entry.EntryType, entry.HandlerName)
+	return err
+}
+
+func (l *sqlSagaLog) GetMessages(tx *sql.Tx, sagaID string) ([]SagaLogEntry, error) {
+	rows, err := tx.Query(fmt.Sprintf("SELECT entry_type, handler_name FROM %s WHERE saga_id = $1 ORDER BY id ASC", l.tableName), sagaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]SagaLogEntry, 0)
+	for rows.Next() {
+		entry := SagaLogEntry{SagaID: sagaID}
+		if scanErr := rows.Scan(&entry.EntryType, &entry.HandlerName); scanErr != nil {
+			return nil, scanErr
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func (l *sqlSagaLog) GetActiveSagas(tx *sql.Tx) ([]string, error) {
+	rows, err := tx.Query(fmt.Sprintf(
+		`SELECT DISTINCT started.saga_id FROM %s started
+			WHERE started.entry_type = $1
+			AND NOT EXISTS (
+				SELECT 1 FROM %s done
+				WHERE done.saga_id = started.saga_id AND done.entry_type IN ($2, $3)
+			)`, l.tableName, l.tableName), StartSaga, EndSaga, AbortSaga)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if scanErr := rows.Scan(&id); scanErr != nil {
+			return nil, scanErr
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+//pendingForwardSteps returns true if entries show more StartTask than EndTask events,
+//meaning the saga crashed while a forward step was in flight
+func pendingForwardSteps(entries []SagaLogEntry) bool {
+	started, ended := 0, 0
+	for _, e := range entries {
+		switch e.EntryType {
+		case StartTask:
+			started++
+		case EndTask:
+			ended++
+		}
+	}
+	return started > ended
+}
+
+//completedHandlerNames returns the handler names of every forward step that completed,
+//in the order they completed
+func completedHandlerNames(entries []SagaLogEntry) []string {
+	completed := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.EntryType == EndTask {
+			completed = append(completed, e.HandlerName)
+		}
+	}
+	return completed
+}
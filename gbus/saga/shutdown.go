@@ -0,0 +1,73 @@
+package saga
+
+import (
+	"context"
+	"sync"
+)
+
+//invocationTracker tracks every active SagaHandler/invokeSagaInstance/TimeoutSaga call so that
+//Stop can wait for them to drain and, if its deadline expires first, cancel their per-invocation
+//contexts instead of leaving them to run unbounded
+type invocationTracker struct {
+	guard   sync.Mutex
+	wg      sync.WaitGroup
+	cancels map[int64]context.CancelFunc
+	nextID  int64
+	stopped bool
+}
+
+func newInvocationTracker() *invocationTracker {
+	return &invocationTracker{cancels: make(map[int64]context.CancelFunc)}
+}
+
+//begin registers a new in-flight invocation derived from parent and returns its context along
+//with a done func that must be called exactly once when the invocation finishes. ok is false if
+//the tracker is already draining, in which case the caller must not dispatch the invocation.
+func (t *invocationTracker) begin(parent context.Context) (ctx context.Context, done func(), ok bool) {
+	t.guard.Lock()
+	if t.stopped {
+		t.guard.Unlock()
+		return nil, nil, false
+	}
+	id := t.nextID
+	t.nextID++
+	ctx, cancel := context.WithCancel(parent)
+	t.cancels[id] = cancel
+	t.wg.Add(1)
+	t.guard.Unlock()
+
+	done = func() {
+		t.guard.Lock()
+		delete(t.cancels, id)
+		t.guard.Unlock()
+		cancel()
+		t.wg.Done()
+	}
+	return ctx, done, true
+}
+
+//drain stops accepting new invocations and waits for the in-flight ones to finish, cancelling
+//their contexts if ctx expires first
+func (t *invocationTracker) drain(ctx context.Context) error {
+	t.guard.Lock()
+	t.stopped = true
+	t.guard.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		t.guard.Lock()
+		for _, cancel := range t.cancels {
+			cancel()
+		}
+		t.guard.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,102 @@
+package saga
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInvocationTrackerDrainsCompletedInvocations(t *testing.T) {
+	tracker := newInvocationTracker()
+
+	_, done, ok := tracker.begin(context.Background())
+	if !ok {
+		t.Fatal("begin() returned ok = false on a fresh tracker")
+	}
+	done()
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := tracker.drain(drainCtx); err != nil {
+		t.Fatalf("drain() returned %v, want nil", err)
+	}
+}
+
+func TestInvocationTrackerRejectsNewInvocationsAfterDrainStarts(t *testing.T) {
+	tracker := newInvocationTracker()
+
+	_, done, ok := tracker.begin(context.Background())
+	if !ok {
+		t.Fatal("begin() returned ok = false on a fresh tracker")
+	}
+
+	drainDone := make(chan error, 1)
+	go func() {
+		drainDone <- tracker.drain(context.Background())
+	}()
+
+	// give drain a chance to flip stopped before we try to register a late invocation
+	time.Sleep(10 * time.Millisecond)
+	if _, _, ok := tracker.begin(context.Background()); ok {
+		t.Fatal("begin() returned ok = true after drain had started")
+	}
+
+	done()
+
+	select {
+	case err := <-drainDone:
+		if err != nil {
+			t.Fatalf("drain() returned %v, want nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("drain() did not return after its only in-flight invocation completed")
+	}
+}
+
+func TestInvocationTrackerCancelsInFlightContextsOnDeadline(t *testing.T) {
+	tracker := newInvocationTracker()
+
+	ctx, done, ok := tracker.begin(context.Background())
+	if !ok {
+		t.Fatal("begin() returned ok = false on a fresh tracker")
+	}
+	defer done()
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := tracker.drain(drainCtx)
+	if err == nil {
+		t.Fatal("drain() returned nil, want the drain context's deadline error")
+	}
+	select {
+	case <-ctx.Done():
+	default:
+		t.Fatal("in-flight invocation's context was not cancelled once the drain deadline expired")
+	}
+}
+
+func TestInvocationTrackerConcurrentBeginDone(t *testing.T) {
+	tracker := newInvocationTracker()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, done, ok := tracker.begin(context.Background())
+			if !ok {
+				return
+			}
+			done()
+		}()
+	}
+	wg.Wait()
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := tracker.drain(drainCtx); err != nil {
+		t.Fatalf("drain() returned %v, want nil", err)
+	}
+}
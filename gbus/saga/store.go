@@ -0,0 +1,235 @@
+package saga
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/wework/grabbit/gbus"
+)
+
+//Store persists saga instance snapshots, keyed by saga id and type, alongside the
+//append-only history kept by SagaLog. It is the snapshot half of crash recovery:
+//SagaLog tells Glue which sagas were active and what they had completed, Store
+//holds the actual instance state to resume or compensate.
+type Store interface {
+	//RegisterSagaType registers the concrete type backing saga so rows can be
+	//(de)serialized into it
+	RegisterSagaType(saga gbus.Saga)
+	//GetSagaByID returns the instance with the given id, or ErrInstanceNotFound if none exists
+	GetSagaByID(tx *sql.Tx, sagaID string) (*Instance, error)
+	//GetSagasByType returns every live instance of sagaType
+	GetSagasByType(tx *sql.Tx, sagaType reflect.Type) ([]*Instance, error)
+	//GetSagaByCorrelationKey returns the instance of sagaType whose indexed correlationKey
+	//column equals value, or nil if none matches
+	GetSagaByCorrelationKey(tx *sql.Tx, sagaType reflect.Type, correlationKey, value string) (*Instance, error)
+	//GetAllActiveSagas returns every live instance across every registered saga type, for the
+	//saga/introspect endpoint
+	GetAllActiveSagas(tx *sql.Tx) ([]*Instance, error)
+	//SaveNewSaga persists newInstance as a new row, indexed under correlationKey/correlationValue
+	//(as extracted by the CorrelationStrategy registered for the message that created it, if
+	//any) so a later GetSagaByCorrelationKey call can find it
+	SaveNewSaga(tx *sql.Tx, sagaType reflect.Type, newInstance *Instance, correlationKey, correlationValue string) error
+	//UpdateSaga persists the current in-memory state of instance
+	UpdateSaga(tx *sql.Tx, instance *Instance) error
+	//DeleteSaga removes instance's row once its saga has completed
+	DeleteSaga(tx *sql.Tx, instance *Instance) error
+	//MarkFailed records that instance's invocation permanently failed with cause, after its
+	//SagaRetryPolicy was exhausted, and persists message so it can later be redelivered via
+	//GetFailedSagaMessage/ResumeFailedSaga
+	MarkFailed(tx *sql.Tx, instance *Instance, message *gbus.BusMessage, cause error) error
+	//GetFailedSagaMessage returns the message persisted by MarkFailed for sagaID
+	GetFailedSagaMessage(tx *sql.Tx, sagaID string) (*gbus.BusMessage, error)
+	//EnsureSchema creates the backing tables if they do not already exist. Callers must invoke
+	//it once against db at service startup, before the first SaveNewSaga/MarkFailed call
+	EnsureSchema(db *sql.DB) error
+}
+
+//sqlStore is the default Store, backed by a SQL table scoped to the hosting service
+type sqlStore struct {
+	tableName       string
+	failedTableName string
+	sagaTypes       map[string]reflect.Type
+}
+
+//NewStore creates a Store that persists instances to a table scoped to svcName
+func NewStore(svcName string) Store {
+	return &sqlStore{
+		tableName:       fmt.Sprintf("%s_saga_instances", svcName),
+		failedTableName: fmt.Sprintf("%s_saga_failures", svcName),
+		sagaTypes:       make(map[string]reflect.Type),
+	}
+}
+
+func (s *sqlStore) EnsureSchema(db *sql.DB) error {
+	_, err := db.Exec(fmt.Sprintf(
+		`CREATE TABLE IF NOT EXISTS %s (
+			saga_id VARCHAR PRIMARY KEY,
+			saga_type VARCHAR NOT NULL,
+			data BYTEA NOT NULL,
+			correlation_key VARCHAR NOT NULL DEFAULT '',
+			correlation_value VARCHAR NOT NULL DEFAULT ''
+		)`, s.tableName))
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(fmt.Sprintf(
+		"CREATE INDEX IF NOT EXISTS %s_correlation_idx ON %s (saga_type, correlation_key, correlation_value)",
+		s.tableName, s.tableName))
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(fmt.Sprintf(
+		`CREATE TABLE IF NOT EXISTS %s (
+			id SERIAL PRIMARY KEY,
+			saga_id VARCHAR NOT NULL,
+			failure_reason VARCHAR NOT NULL,
+			message BYTEA NOT NULL
+		)`, s.failedTableName))
+	return err
+}
+
+func (s *sqlStore) RegisterSagaType(saga gbus.Saga) {
+	sagaType := reflect.TypeOf(saga)
+	s.sagaTypes[sagaType.String()] = sagaType
+}
+
+func (s *sqlStore) GetSagaByID(tx *sql.Tx, sagaID string) (*Instance, error) {
+	row := tx.QueryRow(fmt.Sprintf("SELECT saga_type, data FROM %s WHERE saga_id = $1", s.tableName), sagaID)
+	instance, scanErr := s.scanInstance(row)
+	if scanErr == sql.ErrNoRows {
+		return nil, ErrInstanceNotFound
+	}
+	return instance, scanErr
+}
+
+func (s *sqlStore) GetSagasByType(tx *sql.Tx, sagaType reflect.Type) ([]*Instance, error) {
+	rows, err := tx.Query(fmt.Sprintf("SELECT saga_type, data FROM %s WHERE saga_type = $1", s.tableName), sagaType.String())
+	if err != nil {
+		return nil, err
+	}
+	return s.scanInstances(rows)
+}
+
+func (s *sqlStore) GetSagaByCorrelationKey(tx *sql.Tx, sagaType reflect.Type, correlationKey, value string) (*Instance, error) {
+	row := tx.QueryRow(fmt.Sprintf(
+		"SELECT saga_type, data FROM %s WHERE saga_type = $1 AND correlation_key = $2 AND correlation_value = $3",
+		s.tableName), sagaType.String(), correlationKey, value)
+	instance, scanErr := s.scanInstance(row)
+	if scanErr == sql.ErrNoRows {
+		return nil, nil
+	}
+	return instance, scanErr
+}
+
+func (s *sqlStore) GetAllActiveSagas(tx *sql.Tx) ([]*Instance, error) {
+	rows, err := tx.Query(fmt.Sprintf("SELECT saga_type, data FROM %s", s.tableName))
+	if err != nil {
+		return nil, err
+	}
+	return s.scanInstances(rows)
+}
+
+func (s *sqlStore) SaveNewSaga(tx *sql.Tx, sagaType reflect.Type, newInstance *Instance, correlationKey, correlationValue string) error {
+	data, marshalErr := json.Marshal(newInstance)
+	if marshalErr != nil {
+		return marshalErr
+	}
+	_, err := tx.Exec(fmt.Sprintf(
+		"INSERT INTO %s (saga_id, saga_type, data, correlation_key, correlation_value) VALUES ($1, $2, $3, $4, $5)",
+		s.tableName), newInstance.ID, sagaType.String(), data, correlationKey, correlationValue)
+	return err
+}
+
+//UpdateSaga persists instance's current data only: correlation_key/correlation_value are fixed
+//at SaveNewSaga time and are intentionally left untouched here, so a later UpdateSaga call
+//(which has no inbound message to re-derive a correlation key/value from in every caller, e.g.
+//TimeoutSaga) can never blank out the index a GetSagaByCorrelationKey lookup depends on
+func (s *sqlStore) UpdateSaga(tx *sql.Tx, instance *Instance) error {
+	data, marshalErr := json.Marshal(instance)
+	if marshalErr != nil {
+		return marshalErr
+	}
+	_, err := tx.Exec(fmt.Sprintf("UPDATE %s SET data = $1 WHERE saga_id = $2", s.tableName), data, instance.ID)
+	return err
+}
+
+func (s *sqlStore) DeleteSaga(tx *sql.Tx, instance *Instance) error {
+	_, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE saga_id = $1", s.tableName), instance.ID)
+	return err
+}
+
+func (s *sqlStore) MarkFailed(tx *sql.Tx, instance *Instance, message *gbus.BusMessage, cause error) error {
+	data, marshalErr := json.Marshal(message)
+	if marshalErr != nil {
+		return marshalErr
+	}
+	_, err := tx.Exec(fmt.Sprintf(
+		"INSERT INTO %s (saga_id, failure_reason, message) VALUES ($1, $2, $3)", s.failedTableName),
+		instance.ID, cause.Error(), data)
+	return err
+}
+
+func (s *sqlStore) GetFailedSagaMessage(tx *sql.Tx, sagaID string) (*gbus.BusMessage, error) {
+	row := tx.QueryRow(fmt.Sprintf(
+		"SELECT message FROM %s WHERE saga_id = $1 ORDER BY id DESC LIMIT 1", s.failedTableName), sagaID)
+
+	var data []byte
+	if scanErr := row.Scan(&data); scanErr != nil {
+		if scanErr == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, scanErr
+	}
+
+	message := &gbus.BusMessage{}
+	if err := json.Unmarshal(data, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+type sqlRow interface {
+	Scan(dest ...interface{}) error
+}
+
+func (s *sqlStore) scanInstance(row sqlRow) (*Instance, error) {
+	var sagaTypeName string
+	var data []byte
+	if scanErr := row.Scan(&sagaTypeName, &data); scanErr != nil {
+		return nil, scanErr
+	}
+	return s.unmarshalInstance(sagaTypeName, data)
+}
+
+func (s *sqlStore) scanInstances(rows *sql.Rows) ([]*Instance, error) {
+	defer rows.Close()
+	instances := make([]*Instance, 0)
+	for rows.Next() {
+		var sagaTypeName string
+		var data []byte
+		if scanErr := rows.Scan(&sagaTypeName, &data); scanErr != nil {
+			return nil, scanErr
+		}
+		instance, unmarshalErr := s.unmarshalInstance(sagaTypeName, data)
+		if unmarshalErr != nil {
+			return nil, unmarshalErr
+		}
+		instances = append(instances, instance)
+	}
+	return instances, rows.Err()
+}
+
+func (s *sqlStore) unmarshalInstance(sagaTypeName string, data []byte) (*Instance, error) {
+	sagaType, known := s.sagaTypes[sagaTypeName]
+	if !known {
+		return nil, fmt.Errorf("saga type %s was never registered via RegisterSagaType", sagaTypeName)
+	}
+
+	instance := &Instance{Data: reflect.New(sagaType.Elem()).Interface().(gbus.Saga)}
+	if err := json.Unmarshal(data, instance); err != nil {
+		return nil, err
+	}
+	return instance, nil
+}
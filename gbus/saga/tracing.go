@@ -0,0 +1,35 @@
+package saga
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"strings"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	slog "github.com/opentracing/opentracing-go/log"
+)
+
+//handlerNameFor resolves the name of the func def registered against msgFQN, so traces
+//can be tagged with the concrete handler method that is about to run
+func (d *Def) handlerNameFor(msgFQN string) string {
+	for _, pair := range d.msgToFunc {
+		if strings.EqualFold(pair.Msg.SchemaName(), msgFQN) {
+			if fn := runtime.FuncForPC(reflect.ValueOf(pair.Handler).Pointer()); fn != nil {
+				return fn.Name()
+			}
+		}
+	}
+	return ""
+}
+
+//logSpanEvent appends a log entry for event to the span carried on ctx, if any, as a
+//lightweight way to capture state transitions (new-saga created, saga-completed,
+//timeout-requested, saga-deleted) on the same trace as the message that caused them
+func logSpanEvent(ctx context.Context, event string, fields ...slog.Field) {
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		return
+	}
+	span.LogFields(append([]slog.Field{slog.String("event", event)}, fields...)...)
+}